@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moonduck33/golang-iptools/internal/common"
+	"github.com/moonduck33/golang-iptools/internal/extract"
+	"github.com/moonduck33/golang-iptools/internal/sources"
+)
+
+func runExtract(args []string) error {
+	fs_ := flag.NewFlagSet("extract", flag.ExitOnError)
+	recursive := fs_.Bool("r", false, "recurse into directories")
+	workers := fs_.Int("t", 8, "number of worker goroutines")
+	out := fs_.String("out", "-", "output file, or - for stdout")
+	format := fs_.String("format", "txt", "output format: txt, json, ndjson, csv")
+	quiet := fs_.Bool("quiet", false, "suppress progress logging")
+	if err := fs_.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := common.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	paths := fs_.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	log := common.NewLogger(*quiet)
+
+	files := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if p == "-" || strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://") || strings.HasPrefix(p, "|") {
+			files = append(files, p)
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			log.Warn("can't access path", "path", p, "err", err)
+			continue
+		}
+		if info.IsDir() {
+			if *recursive {
+				filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+					if err != nil {
+						log.Warn("walk error", "path", path, "err", err)
+						return nil
+					}
+					if d.IsDir() {
+						return nil
+					}
+					files = append(files, path)
+					return nil
+				})
+			} else {
+				log.Warn("skipping directory, pass -r to recurse", "path", p)
+			}
+		} else {
+			files = append(files, p)
+		}
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no input files found")
+	}
+
+	taskCh := make(chan io.ReadCloser)
+	resCh := make(chan string)
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rc := range taskCh {
+				if err := extract.FromStream(rc, resCh); err != nil {
+					log.Warn("scan error", "err", err)
+				}
+				rc.Close()
+			}
+		}()
+	}
+
+	go func() {
+		for _, path := range files {
+			rc, err := sources.Open(context.Background(), path, sources.Options{RefreshInterval: 10 * time.Minute})
+			if err != nil {
+				log.Warn("can't open path", "path", path, "err", err)
+				continue
+			}
+			taskCh <- rc
+		}
+		close(taskCh)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(resCh)
+	}()
+
+	domSet := common.NewSet()
+	var domains []string
+	for d := range resCh {
+		if domSet.Add(d) {
+			domains = append(domains, d)
+		}
+	}
+	sort.Strings(domains)
+
+	outW, err := common.OpenOutput(*out)
+	if err != nil {
+		return fmt.Errorf("opening output: %w", err)
+	}
+	defer outW.Close()
+
+	rw, err := common.NewRecordWriter(outW, f, "domain")
+	if err != nil {
+		return err
+	}
+	for _, d := range domains {
+		if err := rw.Write(d); err != nil {
+			return err
+		}
+	}
+	log.Info("done", "domains", len(domains))
+	return rw.Close()
+}
@@ -0,0 +1,44 @@
+// Command iptools bundles the domain resolver, CIDR ranger and domain
+// extractor behind one binary so they can be piped together, e.g.:
+//
+//	iptools extract -r ./corpus | iptools resolve - | iptools range -
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+var subcommands = map[string]func(args []string) error{
+	"resolve": runResolve,
+	"range":   runRange,
+	"extract": runExtract,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := subcommands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "iptools: unknown subcommand %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "iptools:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: iptools <command> [flags]
+
+commands:
+  resolve   resolve domains to IP addresses
+  range     expand CIDRs/IPs into host addresses
+  extract   pull unique domains out of text`)
+}
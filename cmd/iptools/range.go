@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/moonduck33/golang-iptools/internal/common"
+	"github.com/moonduck33/golang-iptools/internal/rangeexpand"
+	"github.com/moonduck33/golang-iptools/internal/sources"
+)
+
+func runRange(args []string) error {
+	fs := flag.NewFlagSet("range", flag.ExitOnError)
+	in := fs.String("in", "-", "input file of CIDRs/IPs, or - for stdin")
+	out := fs.String("out", "-", "output file, or - for stdout")
+	format := fs.String("format", "txt", "output format: txt, json, ndjson, csv")
+	quiet := fs.Bool("quiet", false, "suppress progress logging")
+	max := fs.Int("max", 0, "maximum number of addresses to emit per entry (0 = unlimited)")
+	sample := fs.Int("sample", 1, "emit every Nth host instead of every host")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := common.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	inR, err := sources.Open(context.Background(), *in, sources.Options{})
+	if err != nil {
+		return fmt.Errorf("opening input: %w", err)
+	}
+	defer inR.Close()
+
+	outW, err := common.OpenOutput(*out)
+	if err != nil {
+		return fmt.Errorf("opening output: %w", err)
+	}
+	defer outW.Close()
+
+	rw, err := common.NewRecordWriter(outW, f, "ip")
+	if err != nil {
+		return err
+	}
+
+	log := common.NewLogger(*quiet)
+	seen := common.NewSet()
+	opts := rangeexpand.Options{Max: *max, Sample: *sample}
+
+	scanner := bufio.NewScanner(inR)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || !seen.Add(line) {
+			continue
+		}
+
+		prefix, err := rangeexpand.ParsePrefix(line)
+		if err != nil {
+			log.Warn("skipped entry", "entry", line, "err", err)
+			continue
+		}
+
+		if err := rangeexpand.ExpandPrefix(prefix, recordSink{rw}, opts); err != nil {
+			log.Warn("write error", "entry", line, "err", err)
+			continue
+		}
+		log.Info("expanded", "entry", line)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return rw.Close()
+}
+
+// recordSink adapts a RecordWriter to the io.Writer ExpandPrefix expects,
+// treating each Fprintln'd line as one record.
+type recordSink struct {
+	rw *common.RecordWriter
+}
+
+func (s recordSink) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+	if err := s.rw.Write(line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/moonduck33/golang-iptools/internal/common"
+	"github.com/moonduck33/golang-iptools/internal/ipset"
+	"github.com/moonduck33/golang-iptools/internal/resolver"
+	"github.com/moonduck33/golang-iptools/internal/sources"
+)
+
+func runResolve(args []string) error {
+	fs := flag.NewFlagSet("resolve", flag.ExitOnError)
+	in := fs.String("in", "-", "input file of domains, or - for stdin")
+	out := fs.String("out", "-", "output file, or - for stdout")
+	threads := fs.Int("threads", 100, "worker goroutines")
+	format := fs.String("format", "txt", "output format: txt, json, ndjson, csv")
+	quiet := fs.Bool("quiet", false, "suppress progress logging")
+	dns := fs.String("dns", "", "comma-separated plaintext DNS servers, e.g. 1.1.1.1,8.8.8.8")
+	doh := fs.String("doh", "", "comma-separated DNS-over-HTTPS endpoints")
+	dot := fs.String("dot", "", "comma-separated DNS-over-TLS servers")
+	retries := fs.Int("retries", 2, "retries per upstream on SERVFAIL/timeout")
+	timeout := fs.Duration("timeout", 5*time.Second, "per-lookup timeout")
+	all := fs.Bool("all", false, "write every resolved address instead of just the best-ranked one")
+	dedupeDB := fs.String("dedupe-db", "iptools-resolve.bloom", "path to the persistent Bloom filter used to dedupe seen IPs across runs")
+	dedupeLog := fs.String("dedupe-log", "iptools-resolve.bloom.log", "path to the exact-entry log backing the dedupe filter across runs (empty to disable)")
+	expectedN := fs.Int("expected-n", 1_000_000, "expected number of distinct IPs, used to size the dedupe filter")
+	fpr := fs.Float64("fpr", 0.001, "target false-positive rate for the dedupe filter")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := common.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	res, err := resolver.New(resolver.Config{
+		DNS:     splitList(*dns),
+		DoH:     splitList(*doh),
+		DoT:     splitList(*dot),
+		Retries: *retries,
+	})
+	if err != nil {
+		return fmt.Errorf("configuring resolver: %w", err)
+	}
+
+	domains, err := readDomains(*in)
+	if err != nil {
+		return fmt.Errorf("reading input: %w", err)
+	}
+
+	outW, err := common.OpenOutput(*out)
+	if err != nil {
+		return fmt.Errorf("opening output: %w", err)
+	}
+	defer outW.Close()
+
+	rw, err := common.NewConcurrentRecordWriter(outW, f, "ip")
+	if err != nil {
+		return err
+	}
+
+	log := common.NewLogger(*quiet)
+
+	seen, err := ipset.NewSet(ipset.Options{
+		Path:      *dedupeDB,
+		LogPath:   *dedupeLog,
+		ExpectedN: *expectedN,
+		TargetFPR: *fpr,
+		OnCapacity: func(ip netip.Addr) {
+			log.Warn("dedupe filter at capacity, treating address as a duplicate", "ip", ip, "expected-n", *expectedN)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("opening dedupe filter: %w", err)
+	}
+	defer seen.Close()
+	var resolvedCount int64
+
+	progress := common.NewProgress(500, func(n int) {
+		log.Info("resolved so far", "count", n)
+	})
+
+	var wg sync.WaitGroup
+	domainChan := make(chan string, *threads*2)
+
+	for i := 0; i < *threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for domain := range domainChan {
+				ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+				addrs, err := res.Resolve(ctx, domain)
+				cancel()
+				if err != nil || len(addrs) == 0 {
+					continue
+				}
+
+				ranked := resolver.SelectAddrs(addrs)
+				if !*all && len(ranked) > 1 {
+					ranked = ranked[:1]
+				}
+
+				for _, addr := range ranked {
+					if !seen.Add(addr) {
+						continue
+					}
+					atomic.AddInt64(&resolvedCount, 1)
+					rw.Write(addr.String())
+					progress.Inc()
+				}
+			}
+		}()
+	}
+
+	for _, d := range domains {
+		domainChan <- d
+	}
+	close(domainChan)
+	wg.Wait()
+
+	log.Info("done", "resolved", atomic.LoadInt64(&resolvedCount))
+	return rw.Close()
+}
+
+// readDomains loads a domain feed from a local path, an http(s) URL or an
+// inline "|"-prefixed block, accepting plain one-per-line lists as well as
+// /etc/hosts-style files.
+func readDomains(spec string) ([]string, error) {
+	r, err := sources.Open(context.Background(), spec, sources.Options{RefreshInterval: 10 * time.Minute})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	entries, err := sources.ParseHostsStyle(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, e := range entries {
+		if d := common.NormalizeDomain(e); d != "" {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
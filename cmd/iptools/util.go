@@ -0,0 +1,19 @@
+package main
+
+import "strings"
+
+// splitList parses a comma-separated flag value into its trimmed,
+// non-empty parts.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
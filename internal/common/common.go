@@ -0,0 +1,138 @@
+// Package common holds the utilities shared by the iptools subcommands:
+// dedupe sets, domain normalization, progress reporting and atomic output
+// appends.
+package common
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Set is a concurrency-safe string set used to dedupe IPs and domains
+// across worker goroutines.
+type Set struct {
+	mu sync.Mutex
+	m  map[string]struct{}
+}
+
+// NewSet returns an empty Set.
+func NewSet() *Set {
+	return &Set{m: make(map[string]struct{})}
+}
+
+// Add inserts v and reports whether it was newly added.
+func (s *Set) Add(v string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.m[v]; exists {
+		return false
+	}
+	s.m[v] = struct{}{}
+	return true
+}
+
+// Contains reports whether v is already in the set.
+func (s *Set) Contains(v string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.m[v]
+	return exists
+}
+
+// Len returns the number of elements in the set.
+func (s *Set) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.m)
+}
+
+// NormalizeDomain strips a leading scheme and surrounding whitespace from a
+// raw domain entry, matching the back-compat behavior of the original
+// resolver's input parsing, and lowercases the result.
+func NormalizeDomain(raw string) string {
+	d := strings.TrimSpace(raw)
+	d = strings.TrimPrefix(d, "http://")
+	d = strings.TrimPrefix(d, "https://")
+	return strings.ToLower(d)
+}
+
+// Progress counts completed units of work and logs a milestone every
+// `every` items.
+type Progress struct {
+	every   int
+	onEvery func(count int)
+
+	mu    sync.Mutex
+	count int
+}
+
+// NewProgress returns a Progress that calls onEvery(count) every `every`
+// increments.
+func NewProgress(every int, onEvery func(count int)) *Progress {
+	return &Progress{every: every, onEvery: onEvery}
+}
+
+// Inc records one completed unit of work.
+func (p *Progress) Inc() {
+	p.mu.Lock()
+	p.count++
+	count := p.count
+	p.mu.Unlock()
+
+	if p.every > 0 && count%p.every == 0 && p.onEvery != nil {
+		p.onEvery(count)
+	}
+}
+
+// AtomicAppender serializes writes to a single output sink behind one
+// mutex, instead of every concurrent writer reopening a file or
+// re-implementing its own lock around a shared handle.
+type AtomicAppender struct {
+	mu sync.Mutex
+	w  io.Writer
+	c  io.Closer
+}
+
+// OpenAppender opens path for appending, creating it if necessary.
+func OpenAppender(path string) (*AtomicAppender, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &AtomicAppender{w: f, c: f}, nil
+}
+
+// NewAtomicAppender wraps an already-open sink, serializing writes to it.
+// If w also implements io.Closer, Close closes it.
+func NewAtomicAppender(w io.Writer) *AtomicAppender {
+	c, _ := w.(io.Closer)
+	return &AtomicAppender{w: w, c: c}
+}
+
+// WriteLine appends line followed by a newline.
+func (a *AtomicAppender) WriteLine(line string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err := io.WriteString(a.w, line+"\n")
+	return err
+}
+
+// Locked runs fn while holding the appender's lock, for callers that need
+// to serialize more than a single WriteLine against the same sink (e.g. a
+// format-aware encoder whose own state, not just the underlying writer,
+// must stay consistent across concurrent callers).
+func (a *AtomicAppender) Locked(fn func() error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return fn()
+}
+
+// Close closes the underlying sink, if it supports closing.
+func (a *AtomicAppender) Close() error {
+	if a.c == nil {
+		return nil
+	}
+	return a.c.Close()
+}
@@ -0,0 +1,138 @@
+package common
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Format is an output encoding shared by every subcommand.
+type Format string
+
+const (
+	FormatTXT    Format = "txt"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+	FormatCSV    Format = "csv"
+)
+
+// ParseFormat validates a -format flag value.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatTXT, FormatJSON, FormatNDJSON, FormatCSV:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("unknown format %q (want txt, json, ndjson or csv)", s)
+	}
+}
+
+// RecordWriter writes a stream of single-valued records (an IP, a domain, a
+// range entry) to w in the requested Format. A RecordWriter is safe for
+// concurrent use only when built with NewConcurrentRecordWriter.
+type RecordWriter struct {
+	format   Format
+	field    string
+	w        io.Writer
+	csvW     *csv.Writer
+	jsonN    int
+	appender *AtomicAppender
+}
+
+// NewRecordWriter returns a RecordWriter that encodes each value under the
+// JSON/CSV column name field. The returned writer is for single-goroutine
+// use; see NewConcurrentRecordWriter for writers shared across goroutines.
+func NewRecordWriter(w io.Writer, format Format, field string) (*RecordWriter, error) {
+	return newRecordWriter(w, format, field, nil)
+}
+
+// NewConcurrentRecordWriter returns a RecordWriter safe for concurrent
+// Write/Close calls from multiple goroutines, serialized through an
+// AtomicAppender instead of each caller hand-rolling its own mutex.
+func NewConcurrentRecordWriter(w io.Writer, format Format, field string) (*RecordWriter, error) {
+	return newRecordWriter(w, format, field, NewAtomicAppender(w))
+}
+
+func newRecordWriter(w io.Writer, format Format, field string, appender *AtomicAppender) (*RecordWriter, error) {
+	rw := &RecordWriter{format: format, field: field, w: w, appender: appender}
+
+	switch format {
+	case FormatCSV:
+		rw.csvW = csv.NewWriter(w)
+		if err := rw.csvW.Write([]string{field}); err != nil {
+			return nil, err
+		}
+	case FormatJSON:
+		if _, err := io.WriteString(w, "[\n"); err != nil {
+			return nil, err
+		}
+	}
+	return rw, nil
+}
+
+// Write emits one record.
+func (rw *RecordWriter) Write(value string) error {
+	if rw.appender != nil {
+		return rw.appender.Locked(func() error { return rw.write(value) })
+	}
+	return rw.write(value)
+}
+
+func (rw *RecordWriter) write(value string) error {
+	switch rw.format {
+	case FormatTXT:
+		_, err := fmt.Fprintln(rw.w, value)
+		return err
+	case FormatNDJSON:
+		return rw.writeJSONLine(value, "")
+	case FormatJSON:
+		sep := ",\n"
+		if rw.jsonN == 0 {
+			sep = ""
+		}
+		if _, err := io.WriteString(rw.w, sep); err != nil {
+			return err
+		}
+		if err := rw.writeJSONLine(value, "  "); err != nil {
+			return err
+		}
+		rw.jsonN++
+		return nil
+	case FormatCSV:
+		return rw.csvW.Write([]string{value})
+	default:
+		return fmt.Errorf("unsupported format %q", rw.format)
+	}
+}
+
+func (rw *RecordWriter) writeJSONLine(value, indent string) error {
+	b, err := json.Marshal(map[string]string{rw.field: value})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(rw.w, "%s%s", indent, b)
+	if rw.format == FormatNDJSON {
+		_, err = fmt.Fprintln(rw.w)
+	}
+	return err
+}
+
+// Close finalizes the stream (flushing CSV, closing the JSON array).
+func (rw *RecordWriter) Close() error {
+	if rw.appender != nil {
+		return rw.appender.Locked(rw.close)
+	}
+	return rw.close()
+}
+
+func (rw *RecordWriter) close() error {
+	switch rw.format {
+	case FormatCSV:
+		rw.csvW.Flush()
+		return rw.csvW.Error()
+	case FormatJSON:
+		_, err := io.WriteString(rw.w, "\n]\n")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentRecordWriterJSON guards against interleaved writes
+// corrupting JSON output: concurrent Write calls must each see a
+// consistent jsonN/separator state.
+func TestConcurrentRecordWriterJSON(t *testing.T) {
+	var buf bytes.Buffer
+	rw, err := NewConcurrentRecordWriter(&buf, FormatJSON, "ip")
+	if err != nil {
+		t.Fatalf("NewConcurrentRecordWriter: %v", err)
+	}
+
+	const n = 200
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rw.Write("10.0.0.1")
+		}()
+	}
+	wg.Wait()
+
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var records []map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(records) != n {
+		t.Fatalf("got %d records, want %d", len(records), n)
+	}
+}
+
+func TestAtomicAppenderWriteLine(t *testing.T) {
+	var buf bytes.Buffer
+	a := NewAtomicAppender(&buf)
+
+	const n = 100
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.WriteLine("x")
+		}()
+	}
+	wg.Wait()
+
+	if got := len(strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")); got != n {
+		t.Errorf("got %d lines, want %d", got, n)
+	}
+}
@@ -0,0 +1,32 @@
+package common
+
+import (
+	"io"
+	"log/slog"
+	"os"
+)
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// OpenOutput opens path for writing, truncating any existing file. "-" (or
+// "") writes to stdout, which lets subcommands be chained in a pipeline.
+func OpenOutput(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+// NewLogger returns a text-handler slog.Logger writing to stderr. When
+// quiet is true, only warnings and errors are logged.
+func NewLogger(quiet bool) *slog.Logger {
+	level := slog.LevelInfo
+	if quiet {
+		level = slog.LevelWarn
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
@@ -0,0 +1,47 @@
+// Package extract pulls scheme+host domains out of arbitrary text.
+package extract
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var reURL = regexp.MustCompile(`https?://[^\s"'<>]+`)
+
+// FromStream scans r line by line and sends the scheme+host of every URL it
+// finds to out, repeats included. It does not close r.
+func FromStream(r io.Reader, out chan<- string) error {
+	scanner := bufio.NewScanner(r)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 10*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		for _, m := range reURL.FindAllString(line, -1) {
+			if d := BaseDomain(m); d != "" {
+				out <- d
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// BaseDomain reduces a URL to its lowercased scheme+host, dropping path,
+// query and port.
+func BaseDomain(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	h := u.Host
+	if i := strings.IndexByte(h, ':'); i >= 0 {
+		h = h[:i]
+	}
+	if h == "" {
+		return ""
+	}
+	return u.Scheme + "://" + strings.ToLower(h)
+}
@@ -0,0 +1,168 @@
+package ipset
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// numShards is the number of independent Bloom shards. IPv4 addresses shard
+// by first octet (0-255); IPv6 addresses shard by a hash of their /16
+// modulo numShards. Sharding spreads lock contention across many small mutexes
+// instead of one filter-wide lock.
+const numShards = 256
+
+// bloom is a Bloom filter backed by a memory-mapped file, so its bit array
+// survives across process runs. Its size is fixed when it is created from
+// expectedN and fpr; it does not grow, so its false-positive rate rises
+// above fpr once more than expectedN entries are added. Membership is checked/set with
+// Kirsch-Mitzenmacher double hashing: the k hash positions are derived from
+// two xxhash64 values instead of k independent hash functions.
+type bloom struct {
+	bits     []byte // mmap'd region
+	nBits    uint64
+	k        int
+	shardBy  func(key uint64) uint64
+	shards   [numShards]sync.Mutex
+	shardLen uint64 // bits per shard
+	file     *os.File
+}
+
+// newBloom creates (or reopens) a Bloom filter persisted at path, sized for
+// expectedN entries at the target false-positive rate fpr.
+func newBloom(path string, expectedN int, fpr float64) (*bloom, error) {
+	if expectedN < 1 {
+		expectedN = 1
+	}
+	if fpr <= 0 || fpr >= 1 {
+		fpr = 0.001
+	}
+
+	n := float64(expectedN)
+	m := math.Ceil(-n * math.Log(fpr) / (math.Ln2 * math.Ln2))
+	k := int(math.Round(m / n * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	nBits := uint64(m)
+	// Round up so every shard gets an equal, byte-aligned bit range.
+	shardLen := (nBits/numShards + 1)
+	shardLen += (8 - shardLen%8) % 8
+	nBits = shardLen * numShards
+	nBytes := nBits / 8
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err != nil {
+		f.Close()
+		return nil, err
+	} else if uint64(info.Size()) < nBytes {
+		if err := f.Truncate(int64(nBytes)); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(nBytes), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("ipset: mmap %s: %w", path, err)
+	}
+
+	return &bloom{bits: data, nBits: nBits, k: k, shardLen: shardLen, file: f}, nil
+}
+
+func (b *bloom) close() error {
+	if err := syscall.Munmap(b.bits); err != nil {
+		return err
+	}
+	return b.file.Close()
+}
+
+// bitOffsets returns the k bit offsets within a shard for key, using
+// Kirsch-Mitzenmacher double hashing over two independent xxhash64 values.
+func (b *bloom) bitOffsets(key uint64) []uint64 {
+	h1 := xxhash64(uint64Bytes(key), 0)
+	h2 := xxhash64(uint64Bytes(key), xxPrime5)
+
+	offsets := make([]uint64, b.k)
+	for i := 0; i < b.k; i++ {
+		offsets[i] = (h1 + uint64(i)*h2) % b.shardLen
+	}
+	return offsets
+}
+
+// add sets key's bits within shard and reports whether any of them were
+// previously unset (i.e. this is probably the first time key has been
+// seen).
+func (b *bloom) add(shard int, key uint64) (added bool) {
+	base := uint64(shard) * b.shardLen
+
+	b.shards[shard].Lock()
+	defer b.shards[shard].Unlock()
+
+	for _, off := range b.bitOffsets(key) {
+		bitIdx := base + off
+		byteIdx := bitIdx / 8
+		mask := byte(1) << (bitIdx % 8)
+		if b.bits[byteIdx]&mask == 0 {
+			added = true
+			b.bits[byteIdx] |= mask
+		}
+	}
+	return added
+}
+
+// contains reports whether key might be present in shard (false positives
+// possible, false negatives are not).
+func (b *bloom) contains(shard int, key uint64) bool {
+	base := uint64(shard) * b.shardLen
+
+	b.shards[shard].Lock()
+	defer b.shards[shard].Unlock()
+
+	for _, off := range b.bitOffsets(key) {
+		bitIdx := base + off
+		byteIdx := bitIdx / 8
+		mask := byte(1) << (bitIdx % 8)
+		if b.bits[byteIdx]&mask == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func uint64Bytes(v uint64) []byte {
+	return []byte{
+		byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24),
+		byte(v >> 32), byte(v >> 40), byte(v >> 48), byte(v >> 56),
+	}
+}
+
+// addrKey hashes an IP address down to the uint64 used to index the filter.
+func addrKey(ip netip.Addr) uint64 {
+	b := ip.As16()
+	return xxhash64(b[:], xxPrime3)
+}
+
+// shardForAddr returns which shard an address belongs to: first-octet for
+// IPv4 (numShards is exactly 256, so this is a 1:1 mapping); for IPv6, the
+// top 16 bits (the /16) hashed down to fit numShards. A plain
+// `(b[0]<<8|b[1]) % numShards` would discard b[0] entirely whenever
+// numShards is 256, since that modulus is just the low byte of a
+// big-endian uint16 — hashing first ensures both bytes of the /16
+// influence the shard no matter what numShards is set to.
+func shardForAddr(ip netip.Addr) int {
+	if ip.Is4() {
+		a := ip.As4()
+		return int(a[0])
+	}
+	b := ip.As16()
+	return int(xxhash64(b[:2], xxPrime1) % numShards)
+}
@@ -0,0 +1,24 @@
+package ipset
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestShardForAddrIPv4IsFirstOctet(t *testing.T) {
+	if got := shardForAddr(netip.MustParseAddr("203.0.113.1")); got != 203 {
+		t.Errorf("shardForAddr(203.0.113.1) = %d, want 203", got)
+	}
+}
+
+// TestShardForAddrIPv6UsesBothBytesOfThe16 guards against a plain
+// (b[0]<<8|b[1]) % numShards: with numShards == 256 that modulus reduces to
+// just b[1], silently discarding b[0] and defeating the documented
+// first-octet-like spread across /16s.
+func TestShardForAddrIPv6UsesBothBytesOfThe16(t *testing.T) {
+	a := shardForAddr(netip.MustParseAddr("1234::1"))
+	b := shardForAddr(netip.MustParseAddr("5634::1"))
+	if a == b {
+		t.Errorf("shardForAddr(1234::1) == shardForAddr(5634::1) == %d; both share the low byte of their /16 (0x34) but differ in the high byte, so they must not collide", a)
+	}
+}
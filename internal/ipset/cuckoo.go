@@ -0,0 +1,138 @@
+package ipset
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// cuckoo is a small in-memory cuckoo filter used as the confirmation layer
+// behind the (lossy, persistent) Bloom filter. It stores 16-bit
+// fingerprints, so two keys with the same fingerprint and bucket are not
+// told apart: its false-positive rate is far below the Bloom filter's but
+// not zero. Unlike the Bloom filter it supports deletion, at the cost of not
+// persisting across runs.
+type cuckoo struct {
+	mu      sync.Mutex
+	buckets [][bucketSize]fingerprint
+	mask    uint64
+}
+
+const (
+	bucketSize = 4
+	maxKicks   = 500
+	fpBits     = 16
+	fpMask     = (1 << fpBits) - 1
+)
+
+type fingerprint uint16
+
+// newCuckoo creates a cuckoo filter sized for roughly expectedN entries.
+func newCuckoo(expectedN int) *cuckoo {
+	nBuckets := uint64(1)
+	for nBuckets*bucketSize < uint64(expectedN)*2 {
+		nBuckets <<= 1
+	}
+	if nBuckets < 16 {
+		nBuckets = 16
+	}
+	return &cuckoo{
+		buckets: make([][bucketSize]fingerprint, nBuckets),
+		mask:    nBuckets - 1,
+	}
+}
+
+func (c *cuckoo) indexesAndFP(key uint64) (i1, i2 uint64, fp fingerprint) {
+	h := xxhash64(uint64Bytes(key), xxPrime4)
+	fp = fingerprint(h&fpMask) | 1 // never zero, zero means "empty"
+	i1 = h % (c.mask + 1)
+	i2 = (i1 ^ uint64(xxhash64(uint64Bytes(uint64(fp)), xxPrime2))) % (c.mask + 1)
+	return i1, i2, fp
+}
+
+// add inserts key, evicting and relocating existing fingerprints as needed.
+// It reports false if the filter is full and could not place the entry.
+func (c *cuckoo) add(key uint64) bool {
+	i1, i2, fp := c.indexesAndFP(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.insertInto(i1, fp) || c.insertInto(i2, fp) {
+		return true
+	}
+
+	i := i1
+	if rand.Intn(2) == 1 {
+		i = i2
+	}
+	type slot struct {
+		i uint64
+		j int
+	}
+	kicked := make([]slot, 0, maxKicks)
+	for n := 0; n < maxKicks; n++ {
+		j := rand.Intn(bucketSize)
+		fp, c.buckets[i][j] = c.buckets[i][j], fp
+		kicked = append(kicked, slot{i, j})
+		i = (i ^ uint64(xxhash64(uint64Bytes(uint64(fp)), xxPrime2))) % (c.mask + 1)
+		if c.insertInto(i, fp) {
+			return true
+		}
+	}
+	// Undo the kicks in reverse so no previously stored fingerprint is lost
+	// and the new one is left out.
+	for n := len(kicked) - 1; n >= 0; n-- {
+		s := kicked[n]
+		fp, c.buckets[s.i][s.j] = c.buckets[s.i][s.j], fp
+	}
+	return false
+}
+
+func (c *cuckoo) insertInto(i uint64, fp fingerprint) bool {
+	for j := 0; j < bucketSize; j++ {
+		if c.buckets[i][j] == 0 {
+			c.buckets[i][j] = fp
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether key's fingerprint is present in either of its
+// two buckets; another key sharing that fingerprint and bucket also matches.
+func (c *cuckoo) contains(key uint64) bool {
+	i1, i2, fp := c.indexesAndFP(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.bucketHas(i1, fp) || c.bucketHas(i2, fp)
+}
+
+func (c *cuckoo) bucketHas(i uint64, fp fingerprint) bool {
+	for j := 0; j < bucketSize; j++ {
+		if c.buckets[i][j] == fp {
+			return true
+		}
+	}
+	return false
+}
+
+// remove deletes key, supporting the deletion semantics a Bloom filter
+// cannot provide.
+func (c *cuckoo) remove(key uint64) bool {
+	i1, i2, fp := c.indexesAndFP(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, i := range [2]uint64{i1, i2} {
+		for j := 0; j < bucketSize; j++ {
+			if c.buckets[i][j] == fp {
+				c.buckets[i][j] = 0
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+// Package ipset provides a persistent, sharded membership filter sized for
+// large IP corpora: a memory-mapped Bloom filter for cheap, lossy checks,
+// backed by an in-memory cuckoo filter for a far more precise (though still
+// fingerprint-based) confirmation and for deletion, plus a batched writer for appending newly-seen IPs to disk.
+package ipset
+
+import (
+	"bufio"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+)
+
+// Options configures a Set.
+type Options struct {
+	// Path is the backing file for the Bloom filter's bit array. Required.
+	Path string
+	// ExpectedN sizes the filters for this many entries.
+	ExpectedN int
+	// TargetFPR is the Bloom filter's target false-positive rate, e.g.
+	// 0.001. Defaults to 0.001.
+	TargetFPR float64
+	// LogPath, if set, persists every newly-added address as a plain-text
+	// exact log. The Bloom filter's bits already survive a restart, but the
+	// in-memory cuckoo layer does not; on open, the log (if present) is
+	// replayed to rebuild it, and new entries are appended through a
+	// batched Writer. Leave empty to skip exact persistence.
+	LogPath string
+	// FsyncInterval controls how often the log is flushed and fsynced.
+	// Defaults to DefaultFsyncInterval.
+	FsyncInterval time.Duration
+	// OnCapacity, if set, is called when the exact cuckoo layer is too full
+	// to record a newly-seen address. Add falls back to treating the
+	// address as already seen rather than risk re-adding it on every
+	// future collision; OnCapacity lets the caller log/alert so -expected-n
+	// can be raised.
+	OnCapacity func(ip netip.Addr)
+}
+
+// Set is a sharded, persistent membership filter for netip.Addr values.
+type Set struct {
+	bloom      *bloom
+	exact      *cuckoo
+	writer     *Writer
+	onCapacity func(ip netip.Addr)
+
+	// addMu stripes the lock needed to make Add's check-then-act sequence
+	// (bloom check, exact check, exact add) atomic, by the same shard the
+	// Bloom filter itself uses. Without it, two goroutines racing to add
+	// the same new address can both observe a clean Bloom hit and an empty
+	// exact layer, and both report added.
+	addMu [numShards]sync.Mutex
+}
+
+// NewSet opens (or creates) a Set persisted under opts.Path.
+func NewSet(opts Options) (*Set, error) {
+	b, err := newBloom(opts.Path, opts.ExpectedN, opts.TargetFPR)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Set{bloom: b, exact: newCuckoo(opts.ExpectedN), onCapacity: opts.OnCapacity}
+
+	if opts.LogPath != "" {
+		if err := s.replayLog(opts.LogPath); err != nil {
+			b.close()
+			return nil, err
+		}
+		w, err := NewWriter(opts.LogPath, opts.FsyncInterval)
+		if err != nil {
+			b.close()
+			return nil, err
+		}
+		s.writer = w
+	}
+
+	return s, nil
+}
+
+// replayLog rebuilds the exact cuckoo layer from a prior run's log, since
+// unlike the Bloom filter it isn't memory-mapped and starts out empty.
+func (s *Set) replayLog(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		addr, err := netip.ParseAddr(scanner.Text())
+		if err != nil {
+			continue
+		}
+		s.exact.add(addrKey(addr.Unmap()))
+	}
+	return scanner.Err()
+}
+
+// Add records ip as seen and reports whether it is newly added. The Bloom
+// filter is checked first (cheap, may false-positive); only when it
+// indicates the address might already be present do we pay for the exact
+// cuckoo check. The whole check-then-act sequence is serialized per shard
+// so two goroutines racing to add the same new address can't both win.
+func (s *Set) Add(ip netip.Addr) (added bool) {
+	ip = ip.Unmap()
+	shard := shardForAddr(ip)
+	key := addrKey(ip)
+
+	s.addMu[shard].Lock()
+	defer s.addMu[shard].Unlock()
+
+	if !s.bloom.add(shard, key) {
+		// Bloom filter thought every bit was already set: confirm exactly.
+		if s.exact.contains(key) {
+			return false
+		}
+	}
+
+	if !s.exact.add(key) {
+		// The exact layer is full and couldn't place this fingerprint.
+		// Without it we can't tell a new address from a Bloom false
+		// positive on any future collision, so the safe choice is to treat
+		// this one as already seen instead of re-adding (and re-writing)
+		// it forever.
+		if s.onCapacity != nil {
+			s.onCapacity(ip)
+		}
+		return false
+	}
+
+	if s.writer != nil {
+		s.writer.Enqueue(ip.String())
+	}
+	return true
+}
+
+// Contains reports membership at two confidence levels: maybe (from the
+// Bloom filter, which can false-positive but never false-negatives) and
+// definitely (from the cuckoo filter, only consulted when maybe is true, so
+// callers skip that check entirely on a clean miss). definitely compares
+// 16-bit fingerprints, so it can also be a false positive, though far more
+// rarely than maybe.
+func (s *Set) Contains(ip netip.Addr) (maybe bool, definitely bool) {
+	ip = ip.Unmap()
+	shard := shardForAddr(ip)
+	key := addrKey(ip)
+
+	maybe = s.bloom.contains(shard, key)
+	if !maybe {
+		return false, false
+	}
+	return true, s.exact.contains(key)
+}
+
+// Remove deletes ip from the exact cuckoo layer. The Bloom filter cannot
+// support deletion, so it will keep reporting ip as a "maybe" match;
+// Contains' definitely return value is authoritative after a Remove.
+func (s *Set) Remove(ip netip.Addr) bool {
+	return s.exact.remove(addrKey(ip.Unmap()))
+}
+
+// Close releases the Bloom filter's memory-mapped file and, if configured,
+// drains and closes the exact log writer.
+func (s *Set) Close() error {
+	if s.writer != nil {
+		if err := s.writer.Close(); err != nil {
+			return err
+		}
+	}
+	return s.bloom.close()
+}
+
+// DefaultFsyncInterval is the default batching interval for a Writer fed by
+// a Set's Add calls.
+const DefaultFsyncInterval = time.Second
@@ -0,0 +1,152 @@
+package ipset
+
+import (
+	"net/netip"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func newTestSet(t *testing.T, expectedN int) *Set {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.bloom")
+	s, err := NewSet(Options{Path: path, ExpectedN: expectedN, TargetFPR: 0.001})
+	if err != nil {
+		t.Fatalf("NewSet: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSetAddReportsOnlyFirstInsert(t *testing.T) {
+	s := newTestSet(t, 1000)
+	ip := netip.MustParseAddr("192.0.2.1")
+
+	if !s.Add(ip) {
+		t.Fatal("first Add should report added=true")
+	}
+	if s.Add(ip) {
+		t.Fatal("second Add of the same address should report added=false")
+	}
+}
+
+func TestSetContains(t *testing.T) {
+	s := newTestSet(t, 1000)
+	ip := netip.MustParseAddr("192.0.2.1")
+
+	if maybe, definitely := s.Contains(ip); maybe || definitely {
+		t.Fatalf("Contains before Add = (%v, %v), want (false, false)", maybe, definitely)
+	}
+
+	s.Add(ip)
+
+	maybe, definitely := s.Contains(ip)
+	if !maybe || !definitely {
+		t.Fatalf("Contains after Add = (%v, %v), want (true, true)", maybe, definitely)
+	}
+}
+
+func TestSetAddUnmapsBeforeHashing(t *testing.T) {
+	s := newTestSet(t, 1000)
+
+	plain := netip.MustParseAddr("127.0.0.1")
+	mapped := netip.MustParseAddr("::ffff:127.0.0.1")
+
+	if !s.Add(plain) {
+		t.Fatal("first Add of the plain address should report added=true")
+	}
+	if s.Add(mapped) {
+		t.Fatal("Add of the IPv4-mapped form of an already-seen address should report added=false")
+	}
+}
+
+// TestSetAddConcurrentSameIP reproduces the duplicate-add race: many
+// goroutines racing to Add the same brand-new address must agree on
+// exactly one winner, matching what resolve.go relies on to dedupe a
+// shared CDN/load-balancer IP seen from many worker goroutines at once.
+func TestSetAddConcurrentSameIP(t *testing.T) {
+	s := newTestSet(t, 1000)
+	ip := netip.MustParseAddr("203.0.113.7")
+
+	const workers = 200
+	var wg sync.WaitGroup
+	var addedCount int64
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.Add(ip) {
+				atomic.AddInt64(&addedCount, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if addedCount != 1 {
+		t.Fatalf("got %d goroutines reporting added=true for the same new IP, want exactly 1", addedCount)
+	}
+}
+
+// TestSetAddConcurrentManyIPs exercises the common path of many distinct
+// addresses being added concurrently, each exactly once.
+func TestSetAddConcurrentManyIPs(t *testing.T) {
+	s := newTestSet(t, 10_000)
+
+	const n = 2000
+	ips := make([]netip.Addr, n)
+	for i := 0; i < n; i++ {
+		// Spread across first octets too: the Bloom filter shards by first
+		// octet, and piling every test address into one shard would starve
+		// it relative to ExpectedN and inflate its false-positive rate.
+		ips[i] = netip.AddrFrom4([4]byte{byte(i % 200), byte(i >> 16), byte(i >> 8), byte(i)})
+	}
+
+	var wg sync.WaitGroup
+	var addedCount int64
+	for _, ip := range ips {
+		for r := 0; r < 2; r++ { // each address is submitted twice, racing with its own duplicate
+			wg.Add(1)
+			go func(ip netip.Addr) {
+				defer wg.Done()
+				if s.Add(ip) {
+					atomic.AddInt64(&addedCount, 1)
+				}
+			}(ip)
+		}
+	}
+	wg.Wait()
+
+	if int(addedCount) != n {
+		t.Fatalf("got %d total additions, want exactly %d (one per distinct address)", addedCount, n)
+	}
+}
+
+func TestSetAddSurvivesCuckooCapacity(t *testing.T) {
+	// Size the cuckoo filter tiny so it fills up quickly, and confirm Add
+	// degrades to "treat as duplicate" (via OnCapacity) instead of
+	// reporting added=true forever for addresses it can no longer track.
+	var capacityHits int32
+	path := filepath.Join(t.TempDir(), "test.bloom")
+	s, err := NewSet(Options{
+		Path:      path,
+		ExpectedN: 4,
+		TargetFPR: 0.001,
+		OnCapacity: func(netip.Addr) {
+			atomic.AddInt32(&capacityHits, 1)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSet: %v", err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 4096; i++ {
+		ip := netip.AddrFrom4([4]byte{10, byte(i >> 8), byte(i), 1})
+		s.Add(ip)
+	}
+
+	if atomic.LoadInt32(&capacityHits) == 0 {
+		t.Fatal("expected OnCapacity to be invoked once the tiny cuckoo filter filled up")
+	}
+}
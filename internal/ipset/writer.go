@@ -0,0 +1,82 @@
+package ipset
+
+import (
+	"bufio"
+	"os"
+	"time"
+)
+
+// Writer batches appends to an output file through a single goroutine fed
+// by a buffered channel, fsyncing on a timer instead of per write.
+type Writer struct {
+	lines chan string
+	done  chan struct{}
+}
+
+// NewWriter opens path for appending and starts the batching goroutine.
+// fsyncInterval controls how often buffered writes are flushed and synced
+// to disk; a zero value defaults to one second.
+func NewWriter(path string, fsyncInterval time.Duration) (*Writer, error) {
+	if fsyncInterval <= 0 {
+		fsyncInterval = time.Second
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{
+		lines: make(chan string, 4096),
+		done:  make(chan struct{}),
+	}
+
+	go w.run(f, fsyncInterval)
+	return w, nil
+}
+
+func (w *Writer) run(f *os.File, fsyncInterval time.Duration) {
+	defer close(w.done)
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+	ticker := time.NewTicker(fsyncInterval)
+	defer ticker.Stop()
+
+	dirty := false
+	for {
+		select {
+		case line, ok := <-w.lines:
+			if !ok {
+				bw.Flush()
+				if dirty {
+					f.Sync()
+				}
+				return
+			}
+			bw.WriteString(line)
+			bw.WriteByte('\n')
+			dirty = true
+
+		case <-ticker.C:
+			if dirty {
+				bw.Flush()
+				f.Sync()
+				dirty = false
+			}
+		}
+	}
+}
+
+// Enqueue queues line for a future batched write.
+func (w *Writer) Enqueue(line string) {
+	w.lines <- line
+}
+
+// Close drains the remaining queue, flushes and fsyncs, then closes the
+// underlying file.
+func (w *Writer) Close() error {
+	close(w.lines)
+	<-w.done
+	return nil
+}
@@ -0,0 +1,94 @@
+// Package rangeexpand expands a CIDR (or a plain IP treated as a /24 for
+// back-compat) into its host addresses.
+package rangeexpand
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+)
+
+// Options controls how ExpandPrefix walks a prefix's address space.
+type Options struct {
+	// Max caps the number of addresses written, 0 means unlimited.
+	Max int
+	// Sample, when > 1, emits every Nth host instead of every host.
+	Sample int
+}
+
+// ExpandPrefix streams every host address in prefix to out, one per line.
+// It never materializes the full range in memory, so it is safe to call on
+// large IPv6 prefixes. Respects opts.Max and opts.Sample.
+func ExpandPrefix(prefix netip.Prefix, out io.Writer, opts Options) error {
+	prefix = prefix.Masked()
+	addr := prefix.Addr()
+
+	sample := opts.Sample
+	if sample < 1 {
+		sample = 1
+	}
+
+	written := 0
+	i := 0
+	for {
+		if !prefix.Contains(addr) {
+			break
+		}
+		if i%sample == 0 {
+			if _, err := fmt.Fprintln(out, addr.String()); err != nil {
+				return err
+			}
+			written++
+			if opts.Max > 0 && written >= opts.Max {
+				return nil
+			}
+		}
+		i++
+
+		next := addr.Next()
+		if !next.IsValid() || next == addr {
+			break
+		}
+		addr = next
+	}
+	return nil
+}
+
+// ParsePrefix accepts an explicit CIDR ("1.2.3.0/22", "2001:db8::/48") or a
+// plain IP, which is treated as a /24 for IPv4 back-compat or a /64 for
+// IPv6.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		// Only unmap when the result still fits a 32-bit address: unmapping
+		// ::ffff:127.0.0.0/104 (bits > 32) but keeping bits=104 against a
+		// now-4-byte address would build an out-of-range, invalid Prefix
+		// that Contains silently treats as empty instead of erroring.
+		// Prefixes that don't fit that case (pure IPv6, or a mapped address
+		// with a longer prefix) are returned as parsed.
+		if addr := prefix.Addr(); addr.Is4In6() && prefix.Bits() <= 32 {
+			return netip.PrefixFrom(addr.Unmap(), prefix.Bits()), nil
+		}
+		return prefix, nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	// Unmap first: an IPv4-mapped IPv6 address (e.g. from a resolver that
+	// returns ::ffff:127.0.0.1) is still Is6(), and masking its 128-bit form
+	// with a /24 would mask inside the ::ffff:0:0/96 prefix instead of the
+	// embedded IPv4 address.
+	addr = addr.Unmap()
+
+	bits := 24
+	if addr.Is6() {
+		bits = 64
+	}
+	return addr.Prefix(bits)
+}
@@ -0,0 +1,104 @@
+package rangeexpand
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestParsePrefix(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"1.2.3.0/22", "1.2.3.0/22"},
+		{"2001:db8::/48", "2001:db8::/48"},
+		{"1.2.3.4", "1.2.3.0/24"},
+		{"2001:db8::1", "2001:db8::/64"},
+		// A resolver can hand back an IPv4-mapped IPv6 address; it must be
+		// unmapped before being treated as a /24, not masked inside
+		// ::ffff:0:0/96.
+		{"::ffff:127.0.0.1", "127.0.0.0/24"},
+		// An explicit CIDR on a mapped address with bits > 32 can't be
+		// unmapped without producing an out-of-range Prefix, so it must be
+		// returned as parsed instead.
+		{"::ffff:127.0.0.0/104", "::ffff:127.0.0.0/104"},
+		{"::ffff:0:0/96", "::ffff:0.0.0.0/96"},
+	}
+
+	for _, c := range cases {
+		got, err := ParsePrefix(c.in)
+		if err != nil {
+			t.Errorf("ParsePrefix(%q): unexpected error: %v", c.in, err)
+			continue
+		}
+		if !got.IsValid() {
+			t.Errorf("ParsePrefix(%q) returned an invalid Prefix", c.in)
+			continue
+		}
+		if got.String() != c.want {
+			t.Errorf("ParsePrefix(%q) = %q, want %q", c.in, got.String(), c.want)
+		}
+	}
+}
+
+func TestExpandPrefix(t *testing.T) {
+	prefix := netip.MustParsePrefix("192.0.2.0/30")
+	var buf bytes.Buffer
+	if err := ExpandPrefix(prefix, &buf, Options{}); err != nil {
+		t.Fatalf("ExpandPrefix: %v", err)
+	}
+	got := strings.Fields(buf.String())
+	want := []string{"192.0.2.0", "192.0.2.1", "192.0.2.2", "192.0.2.3"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExpandPrefixMappedBitsOver32(t *testing.T) {
+	prefix, err := ParsePrefix("::ffff:127.0.0.0/126")
+	if err != nil {
+		t.Fatalf("ParsePrefix: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := ExpandPrefix(prefix, &buf, Options{}); err != nil {
+		t.Fatalf("ExpandPrefix: %v", err)
+	}
+	got := strings.Fields(buf.String())
+	if len(got) != 4 {
+		t.Fatalf("got %v, want 4 addresses expanded (not a silent no-op)", got)
+	}
+}
+
+func TestExpandPrefixMaxAndSample(t *testing.T) {
+	prefix := netip.MustParsePrefix("192.0.2.0/28")
+
+	var buf bytes.Buffer
+	if err := ExpandPrefix(prefix, &buf, Options{Max: 3}); err != nil {
+		t.Fatalf("ExpandPrefix: %v", err)
+	}
+	if got := len(strings.Fields(buf.String())); got != 3 {
+		t.Errorf("Max=3: got %d lines, want 3", got)
+	}
+
+	buf.Reset()
+	if err := ExpandPrefix(prefix, &buf, Options{Sample: 4}); err != nil {
+		t.Fatalf("ExpandPrefix: %v", err)
+	}
+	want := []string{"192.0.2.0", "192.0.2.4", "192.0.2.8", "192.0.2.12"}
+	got := strings.Fields(buf.String())
+	if len(got) != len(want) {
+		t.Fatalf("Sample=4: got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Sample=4 line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
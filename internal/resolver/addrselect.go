@@ -0,0 +1,163 @@
+package resolver
+
+import (
+	"net"
+	"net/netip"
+	"sort"
+)
+
+// policyEntry is one row of the RFC 6724 section 2.1 policy table, matched
+// by longest prefix.
+type policyEntry struct {
+	prefix     netip.Prefix
+	precedence int
+	label      int
+}
+
+// defaultPolicyTable is the RFC 6724 policy table used for label and
+// precedence lookups during address selection.
+var defaultPolicyTable = []policyEntry{
+	{prefix: netip.MustParsePrefix("::1/128"), precedence: 50, label: 0},
+	{prefix: netip.MustParsePrefix("::/0"), precedence: 40, label: 1},
+	{prefix: netip.MustParsePrefix("2002::/16"), precedence: 30, label: 2},
+	{prefix: netip.MustParsePrefix("::/96"), precedence: 20, label: 3},
+	{prefix: netip.MustParsePrefix("::ffff:0:0/96"), precedence: 35, label: 4},
+	{prefix: netip.MustParsePrefix("fec0::/10"), precedence: 1, label: 11},
+	{prefix: netip.MustParsePrefix("3ffe::/16"), precedence: 1, label: 12},
+	{prefix: netip.MustParsePrefix("fc00::/7"), precedence: 1, label: 13},
+}
+
+// lookupPolicy returns the longest-prefix-matching policy entry for addr.
+func lookupPolicy(addr netip.Addr) policyEntry {
+	addr = addr.Unmap()
+	if addr.Is4() {
+		addr = netip.AddrFrom16(addr.As16())
+	}
+
+	best := policyEntry{precedence: 1, label: 1}
+	bestBits := -1
+	for _, p := range defaultPolicyTable {
+		if p.prefix.Contains(addr) && p.prefix.Bits() > bestBits {
+			best = p
+			bestBits = p.prefix.Bits()
+		}
+	}
+	return best
+}
+
+// scopeOf approximates the RFC 6724 address scope: 2 (link-local), 5
+// (site-local/ULA) or 14 (global).
+func scopeOf(addr netip.Addr) int {
+	switch {
+	case addr.IsLoopback(), addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast():
+		return 2
+	case addr.IsPrivate():
+		return 5
+	default:
+		return 14
+	}
+}
+
+// candidateSource returns the local address the kernel would use to reach
+// dst, probed via a connected UDP dial (no packets are actually sent).
+func candidateSource(dst netip.Addr) (netip.Addr, bool) {
+	conn, err := net.Dial("udp", net.JoinHostPort(dst.String(), "9"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	defer conn.Close()
+
+	host, _, err := net.SplitHostPort(conn.LocalAddr().String())
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	src, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return src, true
+}
+
+// commonPrefixLen returns the number of leading bits a and b share.
+func commonPrefixLen(a, b netip.Addr) int {
+	a16, b16 := a.As16(), b.As16()
+	n := 0
+	for i := 0; i < 16; i++ {
+		x := a16[i] ^ b16[i]
+		if x == 0 {
+			n += 8
+			continue
+		}
+		for x&0x80 == 0 {
+			n++
+			x <<= 1
+		}
+		break
+	}
+	return n
+}
+
+// candidate pairs a destination with the source address that would be used
+// to reach it.
+type candidate struct {
+	dst      netip.Addr
+	src      netip.Addr
+	hasRoute bool
+}
+
+// SelectAddrs ranks dsts using an RFC 6724-style destination address
+// ordering: reachability, scope match, label match, policy precedence and
+// common prefix length, in that order. The best address is first.
+//
+// Rule 3 ("avoid deprecated addresses") is intentionally not implemented:
+// Go's net package exposes no API for a destination's preferred/deprecated
+// lifetime state (that's interface-address metadata, not something a DNS
+// answer or net.Dial probe can tell us), so there's nothing to rank on.
+func SelectAddrs(dsts []netip.Addr) []netip.Addr {
+	candidates := make([]candidate, len(dsts))
+	for i, dst := range dsts {
+		src, ok := candidateSource(dst)
+		candidates[i] = candidate{dst: dst, src: src, hasRoute: ok}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+
+		// Rule 1: avoid unusable destinations.
+		if a.hasRoute != b.hasRoute {
+			return a.hasRoute
+		}
+		if !a.hasRoute {
+			return false
+		}
+
+		// Rule 2: prefer matching scope.
+		aScopeMatch := scopeOf(a.src) == scopeOf(a.dst)
+		bScopeMatch := scopeOf(b.src) == scopeOf(b.dst)
+		if aScopeMatch != bScopeMatch {
+			return aScopeMatch
+		}
+
+		// Rule 4: prefer matching label.
+		aPolicy, bPolicy := lookupPolicy(a.dst), lookupPolicy(b.dst)
+		aLabelMatch := lookupPolicy(a.src).label == aPolicy.label
+		bLabelMatch := lookupPolicy(b.src).label == bPolicy.label
+		if aLabelMatch != bLabelMatch {
+			return aLabelMatch
+		}
+
+		// Rule 5: prefer higher precedence.
+		if aPolicy.precedence != bPolicy.precedence {
+			return aPolicy.precedence > bPolicy.precedence
+		}
+
+		// Rule 6: prefer longer matching prefix.
+		return commonPrefixLen(a.src, a.dst) > commonPrefixLen(b.src, b.dst)
+	})
+
+	out := make([]netip.Addr, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.dst
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package resolver
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestLookupPolicy(t *testing.T) {
+	cases := []struct {
+		addr           string
+		wantLabel      int
+		wantPrecedence int
+	}{
+		{"::1", 0, 50},
+		{"2001:db8::1", 1, 40},
+		{"::ffff:192.0.2.1", 4, 35},
+		{"fc00::1", 13, 1},
+		{"192.0.2.1", 4, 35}, // IPv4 is treated as ::ffff:0:0/96 after Unmap->As16 widen.
+	}
+
+	for _, c := range cases {
+		addr := netip.MustParseAddr(c.addr)
+		p := lookupPolicy(addr)
+		if p.label != c.wantLabel || p.precedence != c.wantPrecedence {
+			t.Errorf("lookupPolicy(%s) = {label:%d prec:%d}, want {label:%d prec:%d}",
+				c.addr, p.label, p.precedence, c.wantLabel, c.wantPrecedence)
+		}
+	}
+}
+
+func TestScopeOf(t *testing.T) {
+	cases := []struct {
+		addr string
+		want int
+	}{
+		{"127.0.0.1", 2},
+		{"::1", 2},
+		{"169.254.1.1", 2},
+		{"10.0.0.1", 5},
+		{"192.0.2.1", 14},
+		{"2001:db8::1", 14},
+	}
+	for _, c := range cases {
+		got := scopeOf(netip.MustParseAddr(c.addr))
+		if got != c.want {
+			t.Errorf("scopeOf(%s) = %d, want %d", c.addr, got, c.want)
+		}
+	}
+}
+
+func TestCommonPrefixLen(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"2001:db8::1", "2001:db8::2", 126},
+		{"2001:db8::1", "2001:db9::1", 31},
+		{"::", "ffff::", 0},
+		{"::1", "::1", 128},
+	}
+	for _, c := range cases {
+		got := commonPrefixLen(netip.MustParseAddr(c.a), netip.MustParseAddr(c.b))
+		if got != c.want {
+			t.Errorf("commonPrefixLen(%s, %s) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestSelectAddrsPrefersRoutableOverUnroutable(t *testing.T) {
+	// An address in TEST-NET-1 documentation space has no route on a host
+	// without a default route, so it sorts after a loopback address the
+	// kernel can always reach. Where a default route does reach it, loopback
+	// still ranks first on scope match and common prefix length.
+	got := SelectAddrs([]netip.Addr{
+		netip.MustParseAddr("192.0.2.1"),
+		netip.MustParseAddr("127.0.0.1"),
+	})
+	if len(got) != 2 {
+		t.Fatalf("got %d addrs, want 2", len(got))
+	}
+	if got[0] != netip.MustParseAddr("127.0.0.1") {
+		t.Errorf("SelectAddrs = %v, want loopback ranked first", got)
+	}
+}
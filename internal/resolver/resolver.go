@@ -0,0 +1,470 @@
+// Package resolver implements pluggable domain resolution: the system
+// resolver, plaintext DNS against a configured server list, DNS-over-HTTPS
+// and DNS-over-TLS, with round-robin load balancing, retry-with-backoff and
+// RFC 6724-style address selection.
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver resolves a domain to its A and AAAA addresses.
+type Resolver interface {
+	Resolve(ctx context.Context, domain string) ([]netip.Addr, error)
+}
+
+// Config configures the upstreams New builds a Resolver from.
+type Config struct {
+	// DNS is a list of plaintext DNS servers (host or host:port).
+	DNS []string
+	// DoH is a list of DNS-over-HTTPS endpoint URLs.
+	DoH []string
+	// DoT is a list of DNS-over-TLS servers (host or host:port).
+	DoT []string
+	// Retries is the number of retries per upstream on SERVFAIL/timeout
+	// before failing over to the next upstream.
+	Retries int
+	// HTTPClient is used for DoH requests; defaults to a 10s-timeout client
+	// when nil.
+	HTTPClient *http.Client
+}
+
+// New builds a Resolver from cfg, falling back to the system resolver when
+// no upstreams are configured.
+func New(cfg Config) (Resolver, error) {
+	var upstreams []Resolver
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	for _, host := range cfg.DNS {
+		upstreams = append(upstreams, &plainResolver{server: withDefaultPort(host, "53")})
+	}
+	for _, endpoint := range cfg.DoH {
+		upstreams = append(upstreams, &dohResolver{endpoint: endpoint, client: client})
+	}
+	for _, host := range cfg.DoT {
+		upstreams = append(upstreams, &dotResolver{server: withDefaultPort(host, "853")})
+	}
+
+	if len(upstreams) == 0 {
+		return &systemResolver{}, nil
+	}
+
+	return &balancedResolver{upstreams: upstreams, retries: cfg.Retries}, nil
+}
+
+func withDefaultPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// systemResolver defers to the OS resolver and returns both A and AAAA
+// records.
+type systemResolver struct{}
+
+func (s *systemResolver) Resolve(ctx context.Context, domain string) ([]netip.Addr, error) {
+	resolver := &net.Resolver{}
+	ips, err := resolver.LookupNetIP(ctx, "ip", domain)
+	if err != nil {
+		return nil, err
+	}
+	// "ip" network queries can return IPv4 results as IPv4-mapped IPv6
+	// addresses; unmap them so downstream code (address selection, output,
+	// dedupe hashing) sees a plain 4-byte address instead of treating it as
+	// IPv6.
+	for i, ip := range ips {
+		ips[i] = ip.Unmap()
+	}
+	return ips, nil
+}
+
+// balancedResolver round-robins across a set of upstream resolvers and
+// retries with exponential backoff on SERVFAIL or timeout before failing
+// over to the next upstream.
+type balancedResolver struct {
+	upstreams []Resolver
+	retries   int
+
+	mu   sync.Mutex
+	next int
+}
+
+func (b *balancedResolver) Resolve(ctx context.Context, domain string) ([]netip.Addr, error) {
+	b.mu.Lock()
+	start := b.next
+	b.next = (b.next + 1) % len(b.upstreams)
+	b.mu.Unlock()
+
+	var lastErr error
+	for i := 0; i < len(b.upstreams); i++ {
+		up := b.upstreams[(start+i)%len(b.upstreams)]
+
+		backoff := 100 * time.Millisecond
+		for attempt := 0; attempt <= b.retries; attempt++ {
+			addrs, err := up.Resolve(ctx, domain)
+			if err == nil {
+				return addrs, nil
+			}
+			lastErr = err
+			if !isRetryable(err) {
+				break
+			}
+			if attempt < b.retries {
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				backoff *= 2
+			}
+		}
+	}
+	return nil, lastErr
+}
+
+func isRetryable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var rerr *dnsError
+	if errors.As(err, &rerr) {
+		return rerr.rcode == rcodeServFail
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// plainResolver queries a single upstream with plaintext DNS, trying UDP
+// first and falling back to TCP when the response is truncated.
+type plainResolver struct {
+	server string
+}
+
+func (p *plainResolver) Resolve(ctx context.Context, domain string) ([]netip.Addr, error) {
+	return queryBoth(domain, func(qtype uint16) ([]byte, error) {
+		msg := encodeQuery(domain, qtype)
+
+		conn, err := (&net.Dialer{}).DialContext(ctx, "udp", p.server)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		if dl, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(dl)
+		}
+
+		if _, err := conn.Write(msg); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 4096)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		resp := buf[:n]
+		if truncated(resp) {
+			return p.queryTCP(ctx, msg)
+		}
+		return resp, nil
+	})
+}
+
+func (p *plainResolver) queryTCP(ctx context.Context, msg []byte) ([]byte, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", p.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+	return writeReadTCP(conn, msg)
+}
+
+// dotResolver queries a single upstream over DNS-over-TLS (RFC 7858).
+type dotResolver struct {
+	server string
+}
+
+func (d *dotResolver) Resolve(ctx context.Context, domain string) ([]netip.Addr, error) {
+	return queryBoth(domain, func(qtype uint16) ([]byte, error) {
+		msg := encodeQuery(domain, qtype)
+
+		dialer := &tls.Dialer{}
+		conn, err := dialer.DialContext(ctx, "tcp", d.server)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		if dl, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(dl)
+		}
+		return writeReadTCP(conn, msg)
+	})
+}
+
+// dohResolver queries a single DNS-over-HTTPS endpoint using the RFC 8484
+// wire format over POST.
+type dohResolver struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (d *dohResolver) Resolve(ctx context.Context, domain string) ([]netip.Addr, error) {
+	return queryBoth(domain, func(qtype uint16) ([]byte, error) {
+		msg := encodeQuery(domain, qtype)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint, bytes.NewReader(msg))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/dns-message")
+		req.Header.Set("Accept", "application/dns-message")
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("doh: unexpected status %s", resp.Status)
+		}
+		return io.ReadAll(resp.Body)
+	})
+}
+
+// queryBoth runs query for both A and AAAA records and merges the results.
+func queryBoth(domain string, query func(qtype uint16) ([]byte, error)) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	var lastErr error
+
+	for _, qtype := range []uint16{qTypeA, qTypeAAAA} {
+		raw, err := query(qtype)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		rcode, answers, err := decodeResponse(raw)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if rcode != rcodeSuccess {
+			lastErr = &dnsError{rcode: rcode}
+			continue
+		}
+		addrs = append(addrs, answers...)
+	}
+
+	if len(addrs) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return addrs, nil
+}
+
+func writeReadTCP(conn net.Conn, msg []byte) ([]byte, error) {
+	var lenBuf [2]byte
+	binary.BigEndian.PutUint16(lenBuf[:], uint16(len(msg)))
+	if _, err := conn.Write(append(lenBuf[:], msg...)); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.ReadFull(conn, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	respLen := binary.BigEndian.Uint16(lenBuf[:])
+	resp := make([]byte, respLen)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// --- minimal RFC 1035 DNS message encoding/decoding, shared by the
+// plaintext, DoT and DoH transports above. ---
+
+const (
+	qTypeA    uint16 = 1
+	qTypeAAAA uint16 = 28
+	classIN   uint16 = 1
+
+	rcodeSuccess  = 0
+	rcodeServFail = 2
+)
+
+type dnsError struct {
+	rcode int
+}
+
+func (e *dnsError) Error() string {
+	return fmt.Sprintf("dns: response code %d", e.rcode)
+}
+
+func encodeQuery(domain string, qtype uint16) []byte {
+	var buf bytes.Buffer
+
+	id := uint16(rand.Intn(1 << 16))
+	binary.Write(&buf, binary.BigEndian, id)
+	binary.Write(&buf, binary.BigEndian, uint16(0x0100)) // RD=1
+	binary.Write(&buf, binary.BigEndian, uint16(1))      // QDCOUNT
+	binary.Write(&buf, binary.BigEndian, uint16(0))      // ANCOUNT
+	binary.Write(&buf, binary.BigEndian, uint16(0))      // NSCOUNT
+	binary.Write(&buf, binary.BigEndian, uint16(0))      // ARCOUNT
+
+	for _, label := range strings.Split(strings.TrimSuffix(domain, "."), ".") {
+		buf.WriteByte(byte(len(label)))
+		buf.WriteString(label)
+	}
+	buf.WriteByte(0)
+
+	binary.Write(&buf, binary.BigEndian, qtype)
+	binary.Write(&buf, binary.BigEndian, classIN)
+
+	return buf.Bytes()
+}
+
+// decodeResponse parses a DNS response, returning its response code and any
+// A/AAAA answers.
+func decodeResponse(msg []byte) (rcode int, addrs []netip.Addr, err error) {
+	if len(msg) < 12 {
+		return 0, nil, errors.New("dns: short message")
+	}
+
+	flags := binary.BigEndian.Uint16(msg[2:4])
+	rcode = int(flags & 0x0F)
+	qdcount := binary.BigEndian.Uint16(msg[4:6])
+	ancount := binary.BigEndian.Uint16(msg[6:8])
+
+	off := 12
+	for i := 0; i < int(qdcount); i++ {
+		_, next, err := readName(msg, off)
+		if err != nil {
+			return rcode, nil, err
+		}
+		off = next + 4 // qtype + qclass
+	}
+
+	for i := 0; i < int(ancount); i++ {
+		_, next, err := readName(msg, off)
+		if err != nil {
+			return rcode, nil, err
+		}
+		off = next
+		if off+10 > len(msg) {
+			return rcode, nil, errors.New("dns: truncated answer")
+		}
+		rtype := binary.BigEndian.Uint16(msg[off : off+2])
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8 : off+10]))
+		off += 10
+		if off+rdlen > len(msg) {
+			return rcode, nil, errors.New("dns: truncated rdata")
+		}
+		rdata := msg[off : off+rdlen]
+		off += rdlen
+
+		switch rtype {
+		case qTypeA:
+			if addr, ok := netip.AddrFromSlice(rdata); ok && len(rdata) == 4 {
+				addrs = append(addrs, addr)
+			}
+		case qTypeAAAA:
+			if addr, ok := netip.AddrFromSlice(rdata); ok && len(rdata) == 16 {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+
+	return rcode, addrs, nil
+}
+
+// readName decodes a (possibly compressed) DNS name starting at off and
+// returns the name and the offset immediately following it in the message.
+// maxNameJumps bounds the number of compression pointers readName will
+// follow for a single name, and maxNameLength bounds the total decoded
+// length (RFC 1035's 255-byte limit, counting one length byte per label
+// alongside its characters). Both
+// guard against a malformed or adversarial response (e.g. a pointer cycle)
+// looping forever.
+const (
+	maxNameJumps  = 128
+	maxNameLength = 255
+)
+
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	jumped := false
+	end := off
+	jumps := 0
+	total := 0
+
+	for {
+		if off >= len(msg) {
+			return "", 0, errors.New("dns: name out of bounds")
+		}
+		length := int(msg[off])
+
+		if length == 0 {
+			off++
+			if !jumped {
+				end = off
+			}
+			break
+		}
+
+		if length&0xC0 == 0xC0 {
+			if off+1 >= len(msg) {
+				return "", 0, errors.New("dns: bad compression pointer")
+			}
+			jumps++
+			if jumps > maxNameJumps {
+				return "", 0, errors.New("dns: too many compression pointers")
+			}
+			ptr := int(length&0x3F)<<8 | int(msg[off+1])
+			if !jumped {
+				end = off + 2
+				jumped = true
+			}
+			off = ptr
+			continue
+		}
+
+		off++
+		if off+length > len(msg) {
+			return "", 0, errors.New("dns: label out of bounds")
+		}
+		total += length + 1
+		if total > maxNameLength {
+			return "", 0, errors.New("dns: name too long")
+		}
+		labels = append(labels, string(msg[off:off+length]))
+		off += length
+	}
+
+	return strings.Join(labels, "."), end, nil
+}
+
+func truncated(msg []byte) bool {
+	if len(msg) < 4 {
+		return false
+	}
+	flags := binary.BigEndian.Uint16(msg[2:4])
+	return flags&0x0200 != 0
+}
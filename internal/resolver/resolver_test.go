@@ -0,0 +1,124 @@
+package resolver
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestEncodeQuery(t *testing.T) {
+	msg := encodeQuery("example.com", qTypeA)
+
+	if len(msg) < 12 {
+		t.Fatalf("message too short: %d bytes", len(msg))
+	}
+	if qdcount := uint16(msg[4])<<8 | uint16(msg[5]); qdcount != 1 {
+		t.Errorf("QDCOUNT = %d, want 1", qdcount)
+	}
+
+	name, off, err := readName(msg, 12)
+	if err != nil {
+		t.Fatalf("readName: %v", err)
+	}
+	if name != "example.com" {
+		t.Errorf("name = %q, want %q", name, "example.com")
+	}
+
+	qtype := uint16(msg[off])<<8 | uint16(msg[off+1])
+	if qtype != qTypeA {
+		t.Errorf("qtype = %d, want %d", qtype, qTypeA)
+	}
+}
+
+// buildResponse assembles a minimal DNS response with one question and the
+// given answer records, where each answer's name is a compression pointer
+// back to the question.
+func buildResponse(t *testing.T, qtype uint16, answers [][]byte) []byte {
+	t.Helper()
+	msg := encodeQuery("example.com", qtype)
+
+	// ANCOUNT
+	msg[6] = byte(len(answers) >> 8)
+	msg[7] = byte(len(answers))
+
+	for _, rdata := range answers {
+		msg = append(msg, 0xC0, 0x0C) // pointer to offset 12 (the question name)
+		atype := qTypeA
+		if len(rdata) == 16 {
+			atype = qTypeAAAA
+		}
+		msg = append(msg, byte(atype>>8), byte(atype))
+		msg = append(msg, byte(classIN>>8), byte(classIN))
+		msg = append(msg, 0, 0, 0, 60) // TTL
+		msg = append(msg, byte(len(rdata)>>8), byte(len(rdata)))
+		msg = append(msg, rdata...)
+	}
+	return msg
+}
+
+func TestDecodeResponseAAndAAAA(t *testing.T) {
+	a := netip.MustParseAddr("192.0.2.1").As4()
+	aaaa := netip.MustParseAddr("2001:db8::1").As16()
+
+	msg := buildResponse(t, qTypeA, [][]byte{a[:], aaaa[:]})
+
+	rcode, addrs, err := decodeResponse(msg)
+	if err != nil {
+		t.Fatalf("decodeResponse: %v", err)
+	}
+	if rcode != rcodeSuccess {
+		t.Errorf("rcode = %d, want %d", rcode, rcodeSuccess)
+	}
+	if len(addrs) != 2 {
+		t.Fatalf("got %d addrs, want 2", len(addrs))
+	}
+	if addrs[0].String() != "192.0.2.1" {
+		t.Errorf("addrs[0] = %s, want 192.0.2.1", addrs[0])
+	}
+	if addrs[1].String() != "2001:db8::1" {
+		t.Errorf("addrs[1] = %s, want 2001:db8::1", addrs[1])
+	}
+}
+
+func TestReadNamePointerCycleIsBounded(t *testing.T) {
+	// Two labels that point at each other: decoding must error out instead
+	// of looping forever.
+	msg := []byte{
+		0xC0, 0x02, // offset 0: pointer to offset 2
+		0xC0, 0x00, // offset 2: pointer to offset 0
+	}
+
+	done := make(chan struct{})
+	var err error
+	go func() {
+		_, _, err = readName(msg, 0)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		if err == nil {
+			t.Fatal("readName: expected an error for a pointer cycle, got nil")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("readName did not return: pointer cycle was not bounded")
+	}
+}
+
+func TestReadNameOversizedLabelChain(t *testing.T) {
+	// 63-byte labels chained until the name exceeds the 255-byte limit.
+	var msg []byte
+	label := make([]byte, 63)
+	for i := range label {
+		label[i] = 'a'
+	}
+	for i := 0; i < 6; i++ {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+
+	if _, _, err := readName(msg, 0); err == nil {
+		t.Fatal("readName: expected an error for an oversized name, got nil")
+	}
+}
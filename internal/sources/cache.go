@@ -0,0 +1,155 @@
+package sources
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// meta is the on-disk revalidation record for one cached URL.
+type meta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+func cacheDir(opts Options) (string, error) {
+	if opts.CacheDir != "" {
+		return opts.CacheDir, nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "iptools", "sources"), nil
+}
+
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// openHTTP fetches url, revalidating an on-disk cache with ETag/Last-Modified
+// and falling back to the cached copy if the remote fetch fails.
+func openHTTP(ctx context.Context, url string, opts Options) (io.ReadCloser, error) {
+	dir, err := cacheDir(opts)
+	if err != nil {
+		return fetchFresh(ctx, url, opts, meta{})
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fetchFresh(ctx, url, opts, meta{})
+	}
+
+	key := cacheKey(url)
+	bodyPath := filepath.Join(dir, key+".body")
+	metaPath := filepath.Join(dir, key+".meta")
+
+	m := readMeta(metaPath)
+
+	if opts.RefreshInterval > 0 && !m.FetchedAt.IsZero() && time.Since(m.FetchedAt) < opts.RefreshInterval {
+		if f, err := os.Open(bodyPath); err == nil {
+			return f, nil
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if m.ETag != "" {
+		req.Header.Set("If-None-Match", m.ETag)
+	}
+	if m.LastModified != "" {
+		req.Header.Set("If-Modified-Since", m.LastModified)
+	}
+
+	resp, err := opts.client().Do(req)
+	if err != nil {
+		if f, cacheErr := os.Open(bodyPath); cacheErr == nil {
+			return f, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		m.FetchedAt = time.Now()
+		writeMeta(metaPath, m)
+		return os.Open(bodyPath)
+
+	case resp.StatusCode == http.StatusOK:
+		tmp := bodyPath + ".tmp"
+		f, err := os.Create(tmp)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(f, resp.Body); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return nil, err
+		}
+		f.Close()
+		if err := os.Rename(tmp, bodyPath); err != nil {
+			return nil, err
+		}
+
+		writeMeta(metaPath, meta{
+			URL:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			FetchedAt:    time.Now(),
+		})
+		return os.Open(bodyPath)
+
+	default:
+		if f, cacheErr := os.Open(bodyPath); cacheErr == nil {
+			return f, nil
+		}
+		return nil, fmt.Errorf("sources: fetching %s: unexpected status %s", url, resp.Status)
+	}
+}
+
+// fetchFresh performs an uncached GET, used when the on-disk cache directory
+// itself is unavailable.
+func fetchFresh(ctx context.Context, url string, opts Options, m meta) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := opts.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("sources: fetching %s: unexpected status %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func readMeta(path string) meta {
+	var m meta
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return m
+	}
+	_ = json.Unmarshal(b, &m)
+	return m
+}
+
+func writeMeta(path string, m meta) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(path, b, 0644)
+}
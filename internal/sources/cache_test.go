@@ -0,0 +1,138 @@
+package sources
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func readAll(t *testing.T, rc io.ReadCloser) string {
+	t.Helper()
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	return string(b)
+}
+
+func TestOpenHTTPFetchesAndCaches(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("first\n"))
+	}))
+	defer srv.Close()
+
+	opts := Options{CacheDir: t.TempDir()}
+
+	rc, err := openHTTP(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("openHTTP: %v", err)
+	}
+	if got := readAll(t, rc); got != "first\n" {
+		t.Errorf("body = %q, want %q", got, "first\n")
+	}
+	if atomic.LoadInt32(&hits) != 1 {
+		t.Fatalf("hits = %d, want 1", hits)
+	}
+}
+
+func TestOpenHTTPRevalidates304(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("cached body\n"))
+	}))
+	defer srv.Close()
+
+	opts := Options{CacheDir: t.TempDir()}
+
+	rc, err := openHTTP(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("openHTTP (first fetch): %v", err)
+	}
+	readAll(t, rc)
+
+	// RefreshInterval is zero, so this second call always revalidates; the
+	// server must answer 304 and openHTTP must still return the cached body.
+	rc2, err := openHTTP(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("openHTTP (revalidate): %v", err)
+	}
+	if got := readAll(t, rc2); got != "cached body\n" {
+		t.Errorf("body after 304 = %q, want %q", got, "cached body\n")
+	}
+	if atomic.LoadInt32(&hits) != 2 {
+		t.Fatalf("hits = %d, want 2 (fetch + revalidate)", hits)
+	}
+}
+
+func TestOpenHTTPFallsBackToCacheOnFetchFailure(t *testing.T) {
+	var serverUp int32 = 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&serverUp) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("good body\n"))
+	}))
+	defer srv.Close()
+
+	opts := Options{CacheDir: t.TempDir()}
+
+	rc, err := openHTTP(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("openHTTP (first fetch): %v", err)
+	}
+	readAll(t, rc)
+
+	// Origin starts failing; openHTTP must fall back to the cached copy
+	// instead of surfacing the error.
+	atomic.StoreInt32(&serverUp, 0)
+
+	rc2, err := openHTTP(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("openHTTP (origin failing): %v", err)
+	}
+	if got := readAll(t, rc2); got != "good body\n" {
+		t.Errorf("body after origin failure = %q, want fallback to cache %q", got, "good body\n")
+	}
+}
+
+func TestOpenHTTPRefreshIntervalSkipsRevalidation(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte("body\n"))
+	}))
+	defer srv.Close()
+
+	opts := Options{CacheDir: t.TempDir(), RefreshInterval: time.Hour}
+
+	if _, err := openHTTP(context.Background(), srv.URL, opts); err != nil {
+		t.Fatalf("openHTTP (first fetch): %v", err)
+	}
+
+	// Within RefreshInterval: must be served from cache without contacting
+	// the origin at all.
+	rc, err := openHTTP(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("openHTTP (within refresh interval): %v", err)
+	}
+	readAll(t, rc)
+
+	if atomic.LoadInt32(&hits) != 1 {
+		t.Fatalf("hits = %d, want 1 (second call should skip the origin entirely)", hits)
+	}
+}
@@ -0,0 +1,33 @@
+package sources
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+)
+
+// ParseHostsStyle reads a feed that may be either one entry per line or an
+// /etc/hosts-style file ("<ip> <hostname> [alias...]"), stripping "#"
+// comments. When a line's first field parses as an IP address, that field
+// is dropped and the remaining aliases are emitted individually; otherwise
+// every field on the line is emitted as its own entry.
+func ParseHostsStyle(r io.Reader) ([]string, error) {
+	var out []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if net.ParseIP(fields[0]) != nil {
+			fields = fields[1:]
+		}
+		out = append(out, fields...)
+	}
+	return out, scanner.Err()
+}
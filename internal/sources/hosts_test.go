@@ -0,0 +1,49 @@
+package sources
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHostsStyle(t *testing.T) {
+	input := `# a comment line
+127.0.0.1 localhost loopback
+192.0.2.1 example.com www.example.com # trailing comment
+plain-domain.example
+
+`
+	got, err := ParseHostsStyle(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseHostsStyle: %v", err)
+	}
+
+	want := []string{
+		"localhost", "loopback",
+		"example.com", "www.example.com",
+		"plain-domain.example",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseHostsStyleNoLeadingIP(t *testing.T) {
+	got, err := ParseHostsStyle(strings.NewReader("alpha.example beta.example\n"))
+	if err != nil {
+		t.Fatalf("ParseHostsStyle: %v", err)
+	}
+	want := []string{"alpha.example", "beta.example"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
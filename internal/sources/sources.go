@@ -0,0 +1,52 @@
+// Package sources opens input specs shared by every iptools subcommand: a
+// local file path, an http(s):// URL (cached on disk with ETag/Last-Modified
+// revalidation), an inline "|"-prefixed literal block, or "-" for stdin.
+package sources
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Options configures remote fetches. The zero value uses sane defaults.
+type Options struct {
+	// CacheDir holds cached HTTP bodies and their revalidation metadata.
+	// Defaults to os.UserCacheDir()/iptools/sources.
+	CacheDir string
+	// RefreshInterval is how long a cached copy is used without
+	// revalidating against the origin. Zero always revalidates.
+	RefreshInterval time.Duration
+	// HTTPClient performs the fetch; defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+func (o Options) client() *http.Client {
+	if o.HTTPClient != nil {
+		return o.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// Open resolves spec into a readable stream:
+//
+//	"-"                       stdin
+//	"|<text>"                 the literal text following the pipe
+//	"http://..." / "https://" a cached, ETag-revalidated HTTP(S) fetch
+//	anything else             a local file path (an optional leading "@" is
+//	                          stripped, e.g. "@file.txt")
+func Open(ctx context.Context, spec string, opts Options) (io.ReadCloser, error) {
+	switch {
+	case spec == "" || spec == "-":
+		return io.NopCloser(os.Stdin), nil
+	case strings.HasPrefix(spec, "|"):
+		return io.NopCloser(strings.NewReader(strings.TrimPrefix(spec, "|"))), nil
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		return openHTTP(ctx, spec, opts)
+	default:
+		return os.Open(strings.TrimPrefix(spec, "@"))
+	}
+}